@@ -0,0 +1,48 @@
+package introspector
+
+import (
+	"strings"
+
+	"github.com/goldfiglabs/go-introspector/introspector/errdefs"
+	"github.com/pkg/errors"
+)
+
+// capturingHandler forwards every call to the wrapped OutputHandler while
+// also collecting stderr lines, so runCommand can classify a non-zero exit
+// after the fact without changing what callers see.
+type capturingHandler struct {
+	OutputHandler
+	stderr *[]string
+}
+
+func (c capturingHandler) OnStderr(line string) {
+	*c.stderr = append(*c.stderr, line)
+	c.OutputHandler.OnStderr(line)
+}
+
+// classifyExecFailure turns a non-zero introspector.py exit code into one
+// of the errdefs sentinel error types by matching well-known substrings in
+// its captured stderr, falling back to a generic runtime error.
+func classifyExecFailure(exitCode int, stderr []string) error {
+	text := strings.Join(stderr, "\n")
+	cause := errors.Errorf("introspector.py exited with code %v: %v", exitCode, text)
+	switch {
+	case containsAny(text, "AccessDenied", "AuthorizationFailed", "InvalidClientTokenId", "AuthenticationFailed"):
+		return errdefs.NewAuthError(cause)
+	case containsAny(text, "ThrottlingException", "TooManyRequestsException", "RequestLimitExceeded"):
+		return errdefs.NewThrottledError(cause)
+	case containsAny(text, "not supported by introspector", "Unknown service", "No such service"):
+		return errdefs.NewNotFoundError(cause)
+	default:
+		return errdefs.NewRuntimeError(cause)
+	}
+}
+
+func containsAny(text string, substrs ...string) bool {
+	for _, s := range substrs {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package introspector
+
+import (
+	"testing"
+
+	"github.com/goldfiglabs/go-introspector/introspector/errdefs"
+)
+
+func TestClassifyExecFailure(t *testing.T) {
+	cases := []struct {
+		name   string
+		stderr []string
+		check  func(error) bool
+	}{
+		{"aws access denied", []string{"botocore.exceptions.ClientError: An error occurred (AccessDenied)"}, errdefs.IsAuth},
+		{"azure auth failed", []string{"AuthorizationFailed: the client does not have permission"}, errdefs.IsAuth},
+		{"invalid token", []string{"An error occurred (InvalidClientTokenId)"}, errdefs.IsAuth},
+		{"aws throttled", []string{"An error occurred (ThrottlingException): Rate exceeded"}, errdefs.IsThrottled},
+		{"too many requests", []string{"An error occurred (TooManyRequestsException)"}, errdefs.IsThrottled},
+		{"unknown service", []string{"Unknown service: frobnicator"}, errdefs.IsNotFound},
+		{"not supported", []string{"frobnicator is not supported by introspector"}, errdefs.IsNotFound},
+		{"unrecognized failure", []string{"panic: runtime error: index out of range"}, errdefs.IsRuntime},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classifyExecFailure(1, tc.stderr)
+			if !tc.check(err) {
+				t.Errorf("classifyExecFailure(%q) = %v, did not match expected classification", tc.stderr, err)
+			}
+		})
+	}
+}
+
+func TestClassifyExecFailurePreservesStderr(t *testing.T) {
+	err := classifyExecFailure(2, []string{"AccessDenied", "more detail"})
+	want := "introspector.py exited with code 2: AccessDenied\nmore detail"
+	if err.Error() != want {
+		t.Errorf("classifyExecFailure error = %q, want %q", err.Error(), want)
+	}
+}
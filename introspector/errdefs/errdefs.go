@@ -0,0 +1,145 @@
+// Package errdefs gives callers a way to programmatically distinguish why
+// an introspector operation failed, instead of matching on error strings.
+// It follows the same shape as docker/errdefs: a handful of sentinel error
+// types, each implementing a small marker interface, plus Is* helpers that
+// walk a github.com/pkg/errors cause chain looking for one.
+package errdefs
+
+type causer interface {
+	Cause() error
+}
+
+// authError is implemented by errors meaning the caller's credentials were
+// rejected outright (e.g. AWS AccessDenied).
+type authError interface {
+	IsAuth() bool
+}
+
+// throttledError is implemented by errors meaning the call was rate
+// limited and may succeed on retry after a backoff.
+type throttledError interface {
+	IsThrottled() bool
+}
+
+// notFoundError is implemented by errors meaning the requested service or
+// resource doesn't exist, e.g. a serviceSpec introspector doesn't support.
+type notFoundError interface {
+	IsNotFound() bool
+}
+
+// runtimeError is implemented by errors originating from the container
+// runtime itself (docker/podman), as opposed to the cloud API being
+// introspected.
+type runtimeError interface {
+	IsRuntime() bool
+}
+
+// invalidArgError is implemented by errors meaning the caller passed
+// something runCommand's arguments couldn't accept.
+type invalidArgError interface {
+	IsInvalidArg() bool
+}
+
+type taggedError struct {
+	cause error
+	kind  string
+}
+
+func (e *taggedError) Error() string { return e.cause.Error() }
+func (e *taggedError) Cause() error  { return e.cause }
+
+func (e *taggedError) IsAuth() bool       { return e.kind == "auth" }
+func (e *taggedError) IsThrottled() bool  { return e.kind == "throttled" }
+func (e *taggedError) IsNotFound() bool   { return e.kind == "notfound" }
+func (e *taggedError) IsRuntime() bool    { return e.kind == "runtime" }
+func (e *taggedError) IsInvalidArg() bool { return e.kind == "invalidarg" }
+
+// NewAuthError wraps cause as an error the caller's cloud credentials were
+// rejected (e.g. AWS AccessDenied, Azure AuthorizationFailed).
+func NewAuthError(cause error) error { return &taggedError{cause: cause, kind: "auth"} }
+
+// NewThrottledError wraps cause as a rate-limited API call (e.g. AWS
+// ThrottlingException) that may succeed on retry after a backoff.
+func NewThrottledError(cause error) error { return &taggedError{cause: cause, kind: "throttled"} }
+
+// NewNotFoundError wraps cause as a reference to a service or resource
+// introspector doesn't know about.
+func NewNotFoundError(cause error) error { return &taggedError{cause: cause, kind: "notfound"} }
+
+// NewRuntimeError wraps cause as a failure of the container runtime itself
+// (image pull, exec, postgres connectivity), rather than the cloud API.
+func NewRuntimeError(cause error) error { return &taggedError{cause: cause, kind: "runtime"} }
+
+// NewInvalidArgError wraps cause as an invalid argument passed by the
+// caller (a malformed serviceSpec, missing credentials, etc).
+func NewInvalidArgError(cause error) error { return &taggedError{cause: cause, kind: "invalidarg"} }
+
+// IsAuth reports whether err, or any error in its cause chain, was
+// classified as an auth failure.
+func IsAuth(err error) bool {
+	for err != nil {
+		if e, ok := err.(authError); ok && e.IsAuth() {
+			return true
+		}
+		err = cause(err)
+	}
+	return false
+}
+
+// IsThrottled reports whether err, or any error in its cause chain, was
+// classified as a throttled API call.
+func IsThrottled(err error) bool {
+	for err != nil {
+		if e, ok := err.(throttledError); ok && e.IsThrottled() {
+			return true
+		}
+		err = cause(err)
+	}
+	return false
+}
+
+// IsNotFound reports whether err, or any error in its cause chain, was
+// classified as referring to something that doesn't exist.
+func IsNotFound(err error) bool {
+	for err != nil {
+		if e, ok := err.(notFoundError); ok && e.IsNotFound() {
+			return true
+		}
+		err = cause(err)
+	}
+	return false
+}
+
+// IsRuntime reports whether err, or any error in its cause chain, was
+// classified as a container runtime failure.
+func IsRuntime(err error) bool {
+	for err != nil {
+		if e, ok := err.(runtimeError); ok && e.IsRuntime() {
+			return true
+		}
+		err = cause(err)
+	}
+	return false
+}
+
+// IsInvalidArg reports whether err, or any error in its cause chain, was
+// classified as an invalid argument from the caller.
+func IsInvalidArg(err error) bool {
+	for err != nil {
+		if e, ok := err.(invalidArgError); ok && e.IsInvalidArg() {
+			return true
+		}
+		err = cause(err)
+	}
+	return false
+}
+
+// cause returns err's underlying cause if it has one, or nil to stop the
+// walk.
+func cause(err error) error {
+	c, ok := err.(causer)
+	if !ok {
+		return nil
+	}
+	return c.Cause()
+}
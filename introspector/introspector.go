@@ -1,15 +1,14 @@
 package introspector
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"sync"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/pkg/stdcopy"
-	ds "github.com/goldfiglabs/go-introspector/dockersession"
+	"github.com/goldfiglabs/go-introspector/introspector/errdefs"
+	rt "github.com/goldfiglabs/go-introspector/introspector/runtime"
 	ps "github.com/goldfiglabs/go-introspector/postgres"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -18,11 +17,18 @@ import (
 const introspectorRef = "goldfig/introspector:2.1.7"
 const introspectorContainerName = "introspector"
 
-// Service is a wrapper around a docker container running
+// Service is a wrapper around a container running
 // https://github.com/goldfiglabs/introspector.
 type Service struct {
-	ds.ContainerService
-	opts Options
+	ctx         context.Context
+	rt          rt.Runtime
+	containerID string
+	opts        Options
+	postgres    ps.PostgresService
+
+	dbOnce sync.Once
+	dbConn *sql.DB
+	dbErr  error
 }
 
 type Options struct {
@@ -37,25 +43,28 @@ func (o *Options) fillInDefaults() {
 	}
 }
 
-func New(s *ds.Session, postgresService ps.PostgresService, opts Options) (*Service, error) {
+// New starts an introspector container on runtime, wired up against
+// postgresService as its backend. runtime is usually produced by
+// runtime.Detect, but any Runtime implementation works, including Podman's.
+func New(ctx context.Context, runtime rt.Runtime, postgresService ps.PostgresService, opts Options) (*Service, error) {
 	log.Info("Checking for introspector image")
 	opts.fillInDefaults()
 	if !opts.SkipDockerPull {
-		err := s.RequireImage(opts.InspectorRef)
+		err := runtime.PullImage(ctx, opts.InspectorRef)
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to get instrospector docker image")
 		}
 	}
-	service, err := createIntrospectorContainer(s, postgresService, opts)
+	service, err := createIntrospectorContainer(ctx, runtime, postgresService, opts)
 	if err != nil {
 		return nil, err
 	}
-	err = s.Client.ContainerStart(s.Ctx, service.ContainerID, types.ContainerStartOptions{})
+	err = runtime.StartContainer(ctx, service.containerID)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to start introspector")
 	}
 	log.Info("Initializing introspector")
-	err = service.runCommand([]string{"init"}, nil)
+	err = service.runCommand([]string{"init"}, nil, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to init introspector")
 	}
@@ -64,16 +73,84 @@ func New(s *ds.Session, postgresService ps.PostgresService, opts Options) (*Serv
 
 func (i *Service) ImportAWSService(environmentCredentials []string, serviceSpec string) error {
 	return i.runCommand(
-		[]string{"account", "aws", "import", "--force", "--service", serviceSpec}, environmentCredentials)
+		[]string{"account", "aws", "import", "--force", "--service", serviceSpec}, environmentCredentials, nil)
 }
 
-func createIntrospectorContainer(s *ds.Session, postgresService ps.PostgresService, opts Options) (*Service, error) {
-	existingContainer, err := s.FindContainer(introspectorContainerName)
+// ImportGCPService imports a single GCP service's resources. credentialsJSON
+// is the contents of a GCP service-account key file; it is written into the
+// container and referenced via GOOGLE_APPLICATION_CREDENTIALS so the
+// introspector process can authenticate without the key ever touching disk
+// outside the container.
+func (i *Service) ImportGCPService(credentialsJSON []byte, serviceSpec string) error {
+	const credentialsPath = "/tmp/gcp-credentials.json"
+	err := i.rt.CopyToContainer(i.ctx, i.containerID, credentialsPath, credentialsJSON)
+	if err != nil {
+		return errors.Wrap(err, "Failed to copy GCP credentials into introspector")
+	}
+	env := []string{fmt.Sprintf("GOOGLE_APPLICATION_CREDENTIALS=%v", credentialsPath)}
+	return i.runCommand(
+		[]string{"account", "gcp", "import", "--force", "--service", serviceSpec}, env, nil)
+}
+
+// ImportAzureService imports a single Azure service's resources using a
+// service principal identified by tenantID and the given environment
+// credentials (expected to contain AZURE_CLIENT_ID / AZURE_CLIENT_SECRET).
+func (i *Service) ImportAzureService(tenantID, subscriptionID string, environmentCredentials []string, serviceSpec string) error {
+	env := append([]string{}, environmentCredentials...)
+	env = append(env,
+		fmt.Sprintf("AZURE_TENANT_ID=%v", tenantID),
+		fmt.Sprintf("AZURE_SUBSCRIPTION_ID=%v", subscriptionID))
+	return i.runCommand(
+		[]string{"account", "azure", "import", "--force", "--subscription", subscriptionID, "--service", serviceSpec}, env, nil)
+}
+
+// ProviderCredentials holds the credentials ImportAll needs to pass through
+// to whichever provider-specific Import*Service call it fans out to. Only
+// the fields for the provider being imported need to be set.
+type ProviderCredentials struct {
+	AWSEnvironmentCredentials []string
+
+	GCPCredentialsJSON []byte
+
+	AzureTenantID               string
+	AzureSubscriptionID         string
+	AzureEnvironmentCredentials []string
+}
+
+// ImportAll imports every service in services for the given provider
+// ("aws", "gcp", or "azure") using credentials, stopping at the first
+// failure. It is a convenience wrapper around the provider-specific
+// Import*Service calls for callers that just want everything a provider
+// supports.
+func (i *Service) ImportAll(provider string, services []string, credentials ProviderCredentials) error {
+	for _, serviceSpec := range services {
+		var err error
+		switch provider {
+		case "aws":
+			err = i.ImportAWSService(credentials.AWSEnvironmentCredentials, serviceSpec)
+		case "gcp":
+			err = i.ImportGCPService(credentials.GCPCredentialsJSON, serviceSpec)
+		case "azure":
+			err = i.ImportAzureService(
+				credentials.AzureTenantID, credentials.AzureSubscriptionID,
+				credentials.AzureEnvironmentCredentials, serviceSpec)
+		default:
+			return errors.Errorf("Unsupported provider %q", provider)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Failed to import %v service %v", provider, serviceSpec)
+		}
+	}
+	return nil
+}
+
+func createIntrospectorContainer(ctx context.Context, runtime rt.Runtime, postgresService ps.PostgresService, opts Options) (*Service, error) {
+	existingContainer, err := runtime.FindContainer(ctx, introspectorContainerName)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to list existing containers")
 	}
 	if existingContainer != nil {
-		err = s.StopAndRemoveContainer(existingContainer.ID)
+		err = runtime.StopAndRemove(ctx, existingContainer.ID)
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to remove existing container")
 		}
@@ -87,77 +164,87 @@ func createIntrospectorContainer(s *ds.Session, postgresService ps.PostgresServi
 		fmt.Sprintf("INTROSPECTOR_DB_HOST=%v", address.HostIP),
 		fmt.Sprintf("INTROSPECTOR_DB_PORT=%v", address.HostPort),
 	}
-	containerBody, err := s.Client.ContainerCreate(s.Ctx, &container.Config{
-		Image: opts.InspectorRef,
-		Env:   envVars,
-	}, &container.HostConfig{
+	containerID, err := runtime.CreateContainer(ctx, rt.ContainerSpec{
+		Image:       opts.InspectorRef,
+		Name:        introspectorContainerName,
+		Env:         envVars,
 		NetworkMode: "host",
-	}, &network.NetworkingConfig{}, nil, introspectorContainerName)
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create container")
 	}
 	return &Service{
-		ds.ContainerService{ContainerID: containerBody.ID, DockerSession: s},
-		opts,
+		ctx:         ctx,
+		rt:          runtime,
+		containerID: containerID,
+		opts:        opts,
+		postgres:    postgresService,
 	}, nil
 }
 
-type logWriter struct {
-	fn func(args ...interface{})
-}
-
-func (l *logWriter) Write(p []byte) (int, error) {
-	l.fn(string(p))
-	return len(p), nil
-}
-
-func (i *Service) runCommand(args []string, env []string) error {
+// runCommand execs an introspector.py subcommand in the container and
+// streams its output to handler line by line. A nil handler falls back to
+// discarding output, or logging it via logrus if LogDockerOutput is set,
+// matching the historical behavior of this method.
+func (i *Service) runCommand(args []string, env []string, handler OutputHandler) error {
+	if handler == nil {
+		if i.opts.LogDockerOutput {
+			handler = defaultOutputHandler{}
+		} else {
+			handler = discardOutputHandler{}
+		}
+	}
 	envVars := []string{}
 	if env != nil {
 		envVars = append(envVars, env...)
 	}
 	cmdPrefix := []string{"python", "introspector.py"}
 	cmd := append(cmdPrefix, args...)
-	execResp, err := i.DockerSession.Client.ContainerExecCreate(i.DockerSession.Ctx, i.ContainerID, types.ExecConfig{
+	execID, err := i.rt.ExecCreate(i.ctx, i.containerID, rt.ExecConfig{
 		Cmd:          cmd,
+		Env:          envVars,
 		AttachStderr: true,
 		AttachStdout: true,
 		AttachStdin:  true,
-		Env:          envVars,
 	})
 	if err != nil {
-		return errors.Wrap(err, "Failed to create exec")
+		return errdefs.NewRuntimeError(errors.Wrap(err, "Failed to create exec"))
 	}
-	resp, err := i.DockerSession.Client.ContainerExecAttach(i.DockerSession.Ctx, execResp.ID, types.ExecStartCheck{})
+	resp, err := i.rt.ExecAttach(i.ctx, execID)
 	if err != nil {
-		return errors.Wrap(err, "Failed to attach to exec")
+		return errdefs.NewRuntimeError(errors.Wrap(err, "Failed to attach to exec"))
 	}
 	defer resp.Close()
 
+	var stderrLines []string
+	wrapped := capturingHandler{OutputHandler: handler, stderr: &stderrLines}
+	stdout := stdoutLineWriter(wrapped)
+	stderr := stderrLineWriter(wrapped)
+
 	outputDone := make(chan error)
-	if i.opts.LogDockerOutput {
-		errWriter := logWriter{log.Error}
-		infoWriter := logWriter{log.Info}
-		go func() {
-			_, err = stdcopy.StdCopy(&infoWriter, &errWriter, resp.Reader)
-			outputDone <- err
-		}()
-	} else {
-		go func() {
-			_, err = io.Copy(ioutil.Discard, resp.Reader)
-			outputDone <- err
-		}()
-	}
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, resp.Reader())
+		stdout.flush()
+		stderr.flush()
+		outputDone <- err
+	}()
 
 	select {
 	case err := <-outputDone:
 		if err != nil {
-			return err
+			return errdefs.NewRuntimeError(err)
 		}
-		break
 
-	case <-i.DockerSession.Ctx.Done():
-		return i.DockerSession.Ctx.Err()
+	case <-i.ctx.Done():
+		return i.ctx.Err()
+	}
+
+	exitCode, err := i.rt.ExecInspect(i.ctx, execID)
+	if err != nil {
+		return errdefs.NewRuntimeError(errors.Wrap(err, "Failed to inspect exec result"))
+	}
+	if exitCode != 0 {
+		return classifyExecFailure(exitCode, stderrLines)
 	}
 
 	return nil
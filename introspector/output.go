@@ -0,0 +1,109 @@
+package introspector
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Event is a structured progress event emitted by introspector on stdout
+// while an import runs, one JSON object per line.
+type Event struct {
+	Phase    string `json:"phase"`
+	Service  string `json:"service"`
+	Resource string `json:"resource"`
+	Count    int    `json:"count"`
+}
+
+// OutputHandler receives the output of a running introspector command one
+// line at a time. OnStdout/OnStderr are called for plain text lines; any
+// stdout line that parses as a progress Event is delivered via OnEvent
+// instead of OnStdout.
+type OutputHandler interface {
+	OnStdout(line string)
+	OnStderr(line string)
+	OnEvent(evt Event)
+}
+
+// defaultOutputHandler reproduces the historical behavior of runCommand:
+// stdout/stderr lines (and any progress events) are logged via logrus,
+// matching what LogDockerOutput used to do with stdcopy.StdCopy directly.
+type defaultOutputHandler struct{}
+
+func (defaultOutputHandler) OnStdout(line string) { log.Info(line) }
+func (defaultOutputHandler) OnStderr(line string) { log.Error(line) }
+func (defaultOutputHandler) OnEvent(evt Event) {
+	log.Infof("%v: %v %v (%v)", evt.Phase, evt.Service, evt.Resource, evt.Count)
+}
+
+// discardOutputHandler drops everything, matching runCommand's behavior
+// when LogDockerOutput is unset.
+type discardOutputHandler struct{}
+
+func (discardOutputHandler) OnStdout(line string) {}
+func (discardOutputHandler) OnStderr(line string) {}
+func (discardOutputHandler) OnEvent(evt Event)    {}
+
+// lineWriter is an io.Writer that buffers partial lines across Write calls
+// and invokes onLine once per complete line, the way bufio.Scanner would if
+// it could be fed incrementally from stdcopy.StdCopy.
+type lineWriter struct {
+	buf    []byte
+	onLine func(line string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.buf[:idx]), "\r")
+		w.onLine(line)
+		w.buf = w.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+// flush delivers any trailing line left in the buffer once the underlying
+// stream has closed without a final newline.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.onLine(strings.TrimRight(string(w.buf), "\r"))
+		w.buf = nil
+	}
+}
+
+// stdoutLineWriter wraps stdout lines, attempting to parse each as a
+// progress Event before falling back to handler.OnStdout.
+func stdoutLineWriter(handler OutputHandler) *lineWriter {
+	return &lineWriter{onLine: func(line string) {
+		if evt, ok := parseEvent(line); ok {
+			handler.OnEvent(evt)
+			return
+		}
+		handler.OnStdout(line)
+	}}
+}
+
+func stderrLineWriter(handler OutputHandler) *lineWriter {
+	return &lineWriter{onLine: handler.OnStderr}
+}
+
+// parseEvent reports whether line is a introspector progress event, i.e. a
+// JSON object naming a phase. Plain log lines that happen to be valid JSON
+// but don't carry a phase are left alone and treated as stdout text.
+func parseEvent(line string) (Event, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return Event{}, false
+	}
+	var evt Event
+	if err := json.Unmarshal([]byte(trimmed), &evt); err != nil || evt.Phase == "" {
+		return Event{}, false
+	}
+	return evt, true
+}
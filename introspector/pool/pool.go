@@ -0,0 +1,241 @@
+// Package pool orchestrates many introspector containers against a single
+// Postgres backend, fanning out imports across accounts, regions, and
+// services with bounded concurrency and retries.
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/goldfiglabs/go-introspector/introspector"
+	"github.com/goldfiglabs/go-introspector/introspector/errdefs"
+	rt "github.com/goldfiglabs/go-introspector/introspector/runtime"
+	ps "github.com/goldfiglabs/go-introspector/postgres"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// Task is a single unit of import work: one service, for one region, in one
+// account.
+type Task struct {
+	Account string
+	Region  string
+	Service string
+}
+
+// CredentialProvider resolves the environment-style credentials for an
+// account, mirroring the shape ImportAWSService already expects. It is
+// called once per task attempt so rotated/STS credentials can be refreshed
+// between retries.
+type CredentialProvider func(account string) ([]string, error)
+
+// Result is the outcome of running a single Task.
+type Result struct {
+	Task Task
+	Err  error
+}
+
+// Options configures a Pool.
+type Options struct {
+	// Size is the number of introspector containers to keep warm and the
+	// resulting bound on import concurrency.
+	Size int
+	// MaxRetries is the number of additional attempts made for a task after
+	// a transient failure, before it is recorded as failed.
+	MaxRetries int
+	// BackoffBase is the delay before the first retry; each subsequent
+	// retry doubles it.
+	BackoffBase time.Duration
+	// ServiceOptions is passed through to introspector.New for every
+	// container the pool creates.
+	ServiceOptions introspector.Options
+}
+
+func (o *Options) fillInDefaults() {
+	if o.Size == 0 {
+		o.Size = 4
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = 2
+	}
+	if o.BackoffBase == 0 {
+		o.BackoffBase = time.Second
+	}
+}
+
+var (
+	importsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "introspector",
+		Subsystem: "pool",
+		Name:      "imports_in_flight",
+		Help:      "Number of import tasks currently executing.",
+	})
+	importsCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "introspector",
+		Subsystem: "pool",
+		Name:      "imports_completed_total",
+		Help:      "Number of import tasks that completed successfully.",
+	})
+	importsFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "introspector",
+		Subsystem: "pool",
+		Name:      "imports_failed_total",
+		Help:      "Number of import tasks that failed, on a non-retryable error or after exhausting retries.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(importsInFlight, importsCompleted, importsFailed)
+}
+
+// Pool manages a fixed-size set of introspector containers that share one
+// Postgres backend and drains a queue of Tasks across them.
+type Pool struct {
+	opts       Options
+	ctx        context.Context
+	runtime    rt.Runtime
+	postgres   ps.PostgresService
+	credential CredentialProvider
+
+	tasks   chan Task
+	results chan Result
+
+	wg        sync.WaitGroup
+	collected []Result
+	mu        sync.Mutex
+	drained   chan struct{}
+}
+
+// NewPool creates a Pool of opts.Size introspector containers on runtime,
+// all sharing postgresService as their backend. Call Enqueue to add work and
+// Wait to drain it; Enqueue may keep being called for as long as the caller
+// likes before CloseQueue, since a background goroutine drains results as
+// they arrive rather than requiring Wait to be reading them.
+func NewPool(ctx context.Context, runtime rt.Runtime, postgresService ps.PostgresService, credential CredentialProvider, opts Options) (*Pool, error) {
+	opts.fillInDefaults()
+	p := &Pool{
+		opts:       opts,
+		ctx:        ctx,
+		runtime:    runtime,
+		postgres:   postgresService,
+		credential: credential,
+		tasks:      make(chan Task, opts.Size*4),
+		results:    make(chan Result, opts.Size*4),
+		drained:    make(chan struct{}),
+	}
+	for idx := 0; idx < opts.Size; idx++ {
+		svc, err := introspector.New(ctx, runtime, postgresService, opts.ServiceOptions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to start introspector container %v/%v", idx+1, opts.Size)
+		}
+		p.wg.Add(1)
+		go p.worker(svc)
+	}
+	go func() {
+		p.wg.Wait()
+		close(p.results)
+	}()
+	go p.drainResults()
+	return p, nil
+}
+
+// Enqueue adds a task to the work queue. It blocks if every worker is busy
+// and the queue is full.
+func (p *Pool) Enqueue(t Task) {
+	p.tasks <- t
+}
+
+// CloseQueue signals that no more tasks will be enqueued; workers exit once
+// they drain the remaining queue.
+func (p *Pool) CloseQueue() {
+	close(p.tasks)
+}
+
+// drainResults collects worker results into p.collected as they arrive.
+// It runs for the Pool's whole lifetime so results are never left
+// unread: a caller that's still enqueueing hundreds of tasks must not
+// force workers to block sending to a full results channel, since that
+// backs up into tasks and deadlocks Enqueue before it can ever reach
+// CloseQueue/Wait.
+func (p *Pool) drainResults() {
+	for r := range p.results {
+		p.mu.Lock()
+		p.collected = append(p.collected, r)
+		p.mu.Unlock()
+	}
+	close(p.drained)
+}
+
+// Wait blocks until every enqueued task has been attempted and returns the
+// accumulated Results. It returns only after CloseQueue has been called. It
+// is safe to call concurrently with Enqueue, since results are drained by a
+// background goroutine rather than by Wait itself.
+func (p *Pool) Wait() []Result {
+	<-p.drained
+	return p.Results()
+}
+
+// Results returns the results collected so far. Safe to call concurrently
+// with Wait.
+func (p *Pool) Results() []Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Result, len(p.collected))
+	copy(out, p.collected)
+	return out
+}
+
+func (p *Pool) worker(svc *introspector.Service) {
+	defer p.wg.Done()
+	for task := range p.tasks {
+		err := p.runWithRetry(svc, task)
+		p.results <- Result{Task: task, Err: err}
+	}
+}
+
+// runWithRetry retries task against svc up to MaxRetries times, backing off
+// between attempts. It only retries errors that might succeed on a later
+// attempt (throttling, transient runtime failures); auth, invalid-argument,
+// and not-found failures are given up on immediately, since retrying bad
+// credentials or an unsupported service just burns MaxRetries attempts'
+// worth of backoff sleeps before failing the same way.
+func (p *Pool) runWithRetry(svc *introspector.Service, task Task) error {
+	importsInFlight.Inc()
+	defer importsInFlight.Dec()
+
+	backoff := p.opts.BackoffBase
+	var lastErr error
+	attempts := 0
+	for attempt := 0; attempt <= p.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			log.Warnf("Retrying %v/%v/%v (attempt %v) after: %v", task.Account, task.Region, task.Service, attempt+1, lastErr)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		attempts++
+		env, err := p.credential(task.Account)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "Failed to resolve credentials for account %v", task.Account)
+			continue
+		}
+		env = append(env, "AWS_DEFAULT_REGION="+task.Region)
+		lastErr = svc.ImportAWSService(env, task.Service)
+		if lastErr == nil {
+			importsCompleted.Inc()
+			return nil
+		}
+		if errdefs.IsAuth(lastErr) || errdefs.IsInvalidArg(lastErr) || errdefs.IsNotFound(lastErr) {
+			break
+		}
+	}
+	importsFailed.Inc()
+	return errors.Wrapf(lastErr, "Failed to import %v/%v/%v after %v attempts", task.Account, task.Region, task.Service, attempts)
+}
+
+// Metrics exposes the pool's Prometheus collectors for registration with a
+// caller-owned registry.
+func Metrics() []prometheus.Collector {
+	return []prometheus.Collector{importsInFlight, importsCompleted, importsFailed}
+}
@@ -0,0 +1,44 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPoolDrainsResultsConcurrentlyWithEnqueue guards against the deadlock
+// where results piled up faster than a caller still enqueueing tasks could
+// drain them: workers blocked sending to a full results channel, which
+// backed up into tasks and wedged Enqueue before it ever reached
+// CloseQueue/Wait. drainResults must consume results in the background so
+// this can never happen, regardless of buffer size.
+func TestPoolDrainsResultsConcurrentlyWithEnqueue(t *testing.T) {
+	const bufSize = 4
+	const taskCount = bufSize * 10
+
+	p := &Pool{
+		tasks:   make(chan Task, bufSize),
+		results: make(chan Result, bufSize),
+		drained: make(chan struct{}),
+	}
+	go p.drainResults()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < taskCount; i++ {
+			p.results <- Result{Task: Task{Service: "svc"}}
+		}
+		close(p.results)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sending more results than the buffer holds blocked; results are not being drained concurrently")
+	}
+
+	got := p.Wait()
+	if len(got) != taskCount {
+		t.Fatalf("Wait() returned %v results, want %v", len(got), taskCount)
+	}
+}
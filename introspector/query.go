@@ -0,0 +1,136 @@
+package introspector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	// register the postgres driver used by (*Service).db
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// introspectorDatabase is the fixed database name the introspector container
+// creates and imports into; it mirrors INTROSPECTOR_SU_DB_USER et al in
+// createIntrospectorContainer.
+const introspectorDatabase = "introspector"
+
+// Resource is a single row of introspector's canonical `resource` view.
+type Resource struct {
+	URI      string
+	Provider string
+	Service  string
+	Type     string
+}
+
+// ResourceChange describes one difference found by Diff between two
+// imports of the same resource.
+type ResourceChange struct {
+	URI  string
+	Kind string // "added", "removed", or "changed"
+}
+
+// db lazily opens a pooled connection to the Postgres backend the
+// introspector container is already using, so callers can query imported
+// data without reaching into the PostgresService themselves.
+func (i *Service) db() (*sql.DB, error) {
+	i.dbOnce.Do(func() {
+		credential := i.postgres.SuperUserCredential()
+		address := i.postgres.Address()
+		dsn := fmt.Sprintf("host=%v port=%v user=%v password=%v dbname=%v sslmode=disable",
+			address.HostIP, address.HostPort, credential.Username, credential.Password, introspectorDatabase)
+		i.dbConn, i.dbErr = sql.Open("postgres", dsn)
+	})
+	return i.dbConn, i.dbErr
+}
+
+// Query runs an arbitrary SQL query against the introspector database,
+// i.e. the tables populated by Import*Service calls.
+func (i *Service) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	conn, err := i.db()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to connect to introspector database")
+	}
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to run query")
+	}
+	return rows, nil
+}
+
+// ListResources returns every resource of resourceType imported for
+// service under provider (e.g. "aws", "ec2", "instance"). Any of the three
+// may be left empty to match all values.
+func (i *Service) ListResources(ctx context.Context, provider, service, resourceType string) ([]Resource, error) {
+	rows, err := i.Query(ctx, `
+		SELECT uri, provider, service, type
+		FROM resource
+		WHERE ($1 = '' OR provider = $1)
+		  AND ($2 = '' OR service = $2)
+		  AND ($3 = '' OR type = $3)`, provider, service, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	resources := []Resource{}
+	for rows.Next() {
+		var r Resource
+		err = rows.Scan(&r.URI, &r.Provider, &r.Service, &r.Type)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to scan resource row")
+		}
+		resources = append(resources, r)
+	}
+	return resources, errors.Wrap(rows.Err(), "Failed to read resource rows")
+}
+
+// GetResource looks up a single resource by its introspector URI.
+func (i *Service) GetResource(ctx context.Context, uri string) (*Resource, error) {
+	conn, err := i.db()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to connect to introspector database")
+	}
+	var r Resource
+	err = conn.QueryRowContext(ctx, `
+		SELECT uri, provider, service, type FROM resource WHERE uri = $1`, uri).
+		Scan(&r.URI, &r.Provider, &r.Service, &r.Type)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to look up resource %v", uri)
+	}
+	return &r, nil
+}
+
+// Diff compares the resources present after two separate imports and
+// reports what was added, removed, or changed between them.
+func (i *Service) Diff(ctx context.Context, importA, importB int) ([]ResourceChange, error) {
+	rows, err := i.Query(ctx, `
+		SELECT uri, kind FROM (
+			SELECT COALESCE(a.uri, b.uri) AS uri,
+			       CASE
+			         WHEN a.uri IS NULL THEN 'added'
+			         WHEN b.uri IS NULL THEN 'removed'
+			         ELSE 'changed'
+			       END AS kind
+			FROM (SELECT * FROM resource WHERE import_id = $1) a
+			FULL OUTER JOIN (SELECT * FROM resource WHERE import_id = $2) b
+			  ON a.uri = b.uri
+			WHERE a.uri IS DISTINCT FROM b.uri OR a.checksum IS DISTINCT FROM b.checksum
+		) changes`, importA, importB)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	changes := []ResourceChange{}
+	for rows.Next() {
+		var c ResourceChange
+		err = rows.Scan(&c.URI, &c.Kind)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to scan diff row")
+		}
+		changes = append(changes, c)
+	}
+	return changes, errors.Wrap(rows.Err(), "Failed to read diff rows")
+}
@@ -0,0 +1,30 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// Detect picks a Runtime based on CONTAINER_HOST / DOCKER_HOST, preferring
+// CONTAINER_HOST since that's what rootless Podman setups conventionally
+// export. With neither set it falls back to the standard Docker client
+// talking to the default local socket.
+func Detect(ctx context.Context) (Runtime, error) {
+	host := os.Getenv("CONTAINER_HOST")
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if strings.Contains(host, "podman") {
+		return NewPodmanRuntime(ctx, host)
+	}
+	opts := []dockerclient.Opt{dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation()}
+	c, err := dockerclient.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create docker client")
+	}
+	return NewDockerRuntime(c), nil
+}
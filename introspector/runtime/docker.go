@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// dockerRuntime is the default Runtime, talking to a real Docker daemon.
+type dockerRuntime struct {
+	client *dockerclient.Client
+}
+
+// NewDockerRuntime wraps an existing Docker client as a Runtime.
+func NewDockerRuntime(c *dockerclient.Client) Runtime {
+	return &dockerRuntime{client: c}
+}
+
+func (r *dockerRuntime) PullImage(ctx context.Context, ref string) error {
+	reader, err := r.client.ImagePull(ctx, ref, types.ImagePullOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to pull image %v", ref)
+	}
+	defer reader.Close()
+	_, err = io.Copy(io.Discard, reader)
+	return errors.Wrap(err, "Failed to read image pull progress")
+}
+
+func (r *dockerRuntime) FindContainer(ctx context.Context, name string) (*ContainerInfo, error) {
+	containers, err := r.client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list containers")
+	}
+	for _, c := range containers {
+		for _, n := range c.Names {
+			if strings.TrimPrefix(n, "/") == name {
+				return &ContainerInfo{ID: c.ID, Name: name}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (r *dockerRuntime) CreateContainer(ctx context.Context, spec ContainerSpec) (string, error) {
+	body, err := r.client.ContainerCreate(ctx, &container.Config{
+		Image: spec.Image,
+		Env:   spec.Env,
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode(spec.NetworkMode),
+	}, &network.NetworkingConfig{}, nil, spec.Name)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create container")
+	}
+	return body.ID, nil
+}
+
+func (r *dockerRuntime) StartContainer(ctx context.Context, containerID string) error {
+	return errors.Wrap(
+		r.client.ContainerStart(ctx, containerID, types.ContainerStartOptions{}),
+		"Failed to start container")
+}
+
+func (r *dockerRuntime) StopAndRemove(ctx context.Context, containerID string) error {
+	err := r.client.ContainerStop(ctx, containerID, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to stop container")
+	}
+	return errors.Wrap(
+		r.client.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{}),
+		"Failed to remove container")
+}
+
+func (r *dockerRuntime) CopyToContainer(ctx context.Context, containerID, path string, contents []byte) error {
+	archive, err := buildTarArchive(path, contents)
+	if err != nil {
+		return errors.Wrap(err, "Failed to build tar archive")
+	}
+	return errors.Wrap(
+		r.client.CopyToContainer(ctx, containerID, "/", archive, types.CopyToContainerOptions{}),
+		"Failed to copy to container")
+}
+
+func (r *dockerRuntime) ExecCreate(ctx context.Context, containerID string, cfg ExecConfig) (string, error) {
+	resp, err := r.client.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cfg.Cmd,
+		Env:          cfg.Env,
+		AttachStdin:  cfg.AttachStdin,
+		AttachStdout: cfg.AttachStdout,
+		AttachStderr: cfg.AttachStderr,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create exec")
+	}
+	return resp.ID, nil
+}
+
+func (r *dockerRuntime) ExecInspect(ctx context.Context, execID string) (int, error) {
+	inspect, err := r.client.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to inspect exec")
+	}
+	return inspect.ExitCode, nil
+}
+
+func (r *dockerRuntime) ExecAttach(ctx context.Context, execID string) (ExecAttachment, error) {
+	resp, err := r.client.ContainerExecAttach(ctx, execID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to attach to exec")
+	}
+	return &dockerExecAttachment{resp}, nil
+}
+
+// dockerExecAttachment adapts types.HijackedResponse to ExecAttachment.
+type dockerExecAttachment struct {
+	resp types.HijackedResponse
+}
+
+func (a *dockerExecAttachment) Write(p []byte) (int, error) { return a.resp.Conn.Write(p) }
+func (a *dockerExecAttachment) Reader() io.Reader            { return a.resp.Reader }
+func (a *dockerExecAttachment) CloseWrite() error            { return a.resp.CloseWrite() }
+func (a *dockerExecAttachment) Close()                       { a.resp.Close() }
+
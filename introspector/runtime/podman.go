@@ -0,0 +1,203 @@
+package runtime
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/containers/podman/v3/pkg/bindings"
+	"github.com/containers/podman/v3/pkg/bindings/containers"
+	"github.com/containers/podman/v3/pkg/bindings/images"
+	"github.com/containers/podman/v3/pkg/specgen"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+)
+
+// podmanRuntime talks to Podman over its Docker-compatible bindings, for
+// rootless hosts and CI environments without a Docker daemon.
+type podmanRuntime struct {
+	conn context.Context
+}
+
+// NewPodmanRuntime connects to the Podman service at sock (a
+// unix:///run/... or tcp:// URI, as accepted by bindings.NewConnection) and
+// returns a Runtime backed by it.
+func NewPodmanRuntime(ctx context.Context, sock string) (Runtime, error) {
+	conn, err := bindings.NewConnection(ctx, sock)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to connect to podman socket %v", sock)
+	}
+	return &podmanRuntime{conn: conn}, nil
+}
+
+func (r *podmanRuntime) PullImage(ctx context.Context, ref string) error {
+	_, err := images.Pull(r.conn, ref, nil)
+	return errors.Wrapf(err, "Failed to pull image %v", ref)
+}
+
+func (r *podmanRuntime) FindContainer(ctx context.Context, name string) (*ContainerInfo, error) {
+	exists, err := containers.Exists(r.conn, name, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to look up container %v", name)
+	}
+	if !exists {
+		return nil, nil
+	}
+	data, err := containers.Inspect(r.conn, name, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to inspect container %v", name)
+	}
+	return &ContainerInfo{ID: data.ID, Name: name}, nil
+}
+
+func (r *podmanRuntime) CreateContainer(ctx context.Context, spec ContainerSpec) (string, error) {
+	s := specgen.NewSpecGenerator(spec.Image, false)
+	s.Name = spec.Name
+	s.Env = envSliceToMap(spec.Env)
+	if spec.NetworkMode == "host" {
+		s.NetNS.NSMode = specgen.Host
+	}
+	resp, err := containers.CreateWithSpec(r.conn, s, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create container")
+	}
+	return resp.ID, nil
+}
+
+func (r *podmanRuntime) StartContainer(ctx context.Context, containerID string) error {
+	return errors.Wrap(containers.Start(r.conn, containerID, nil), "Failed to start container")
+}
+
+func (r *podmanRuntime) StopAndRemove(ctx context.Context, containerID string) error {
+	err := containers.Stop(r.conn, containerID, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to stop container")
+	}
+	return errors.Wrap(containers.Remove(r.conn, containerID, nil), "Failed to remove container")
+}
+
+func (r *podmanRuntime) CopyToContainer(ctx context.Context, containerID, path string, contents []byte) error {
+	archive, err := buildTarArchive(path, contents)
+	if err != nil {
+		return errors.Wrap(err, "Failed to build tar archive")
+	}
+	copyFunc, err := containers.CopyFromArchive(r.conn, containerID, "/", archive)
+	if err != nil {
+		return errors.Wrap(err, "Failed to start copy to container")
+	}
+	return errors.Wrap(copyFunc(), "Failed to copy to container")
+}
+
+func (r *podmanRuntime) ExecCreate(ctx context.Context, containerID string, cfg ExecConfig) (string, error) {
+	execConfig := &containers.ExecOptions{
+		Cmd:          cfg.Cmd,
+		Env:          envSliceToMap(cfg.Env),
+		AttachStdin:  &cfg.AttachStdin,
+		AttachStdout: &cfg.AttachStdout,
+		AttachStderr: &cfg.AttachStderr,
+	}
+	execID, err := containers.ExecCreate(r.conn, containerID, execConfig)
+	return execID, errors.Wrap(err, "Failed to create exec")
+}
+
+func (r *podmanRuntime) ExecInspect(ctx context.Context, execID string) (int, error) {
+	session, err := containers.ExecInspect(r.conn, execID, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to inspect exec")
+	}
+	return session.ExitCode, nil
+}
+
+// ExecAttach starts the exec and returns an ExecAttachment whose Reader
+// yields Docker stdcopy-framed output, the same format the Docker Runtime
+// produces, so callers can run stdcopy.StdCopy over either implementation
+// unchanged.
+//
+// containers.ExecStartAndAttach is synchronous: it writes output into the
+// streams we hand it before returning. It must run in its own goroutine,
+// and the goroutines draining its output streams must already be reading
+// before it starts, or its first write blocks forever against an
+// unbuffered pipe that nothing is reading.
+func (r *podmanRuntime) ExecAttach(ctx context.Context, execID string) (ExecAttachment, error) {
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	combinedR, combinedW := io.Pipe()
+	stdinR, stdinW := io.Pipe()
+
+	var writeMu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go pumpStdcopyFrames(stdoutR, stdcopy.Stdout, combinedW, &writeMu, &wg)
+	go pumpStdcopyFrames(stderrR, stdcopy.Stderr, combinedW, &writeMu, &wg)
+
+	go func() {
+		attachErr := containers.ExecStartAndAttach(r.conn, execID, &containers.ExecStartAndAttachOptions{
+			OutputStream: stdoutW,
+			ErrorStream:  stderrW,
+			InputStream:  stdinR,
+		})
+		stdoutW.CloseWithError(attachErr)
+		stderrW.CloseWithError(attachErr)
+		wg.Wait()
+		combinedW.CloseWithError(attachErr)
+	}()
+
+	return &podmanExecAttachment{reader: combinedR, writer: stdinW}, nil
+}
+
+// pumpStdcopyFrames copies src into dst wrapped in Docker's stdcopy frame
+// header (stream type byte + big-endian length), serializing writes with mu
+// since stdout and stderr share one destination pipe.
+func pumpStdcopyFrames(src *io.PipeReader, streamType stdcopy.StdType, dst io.Writer, mu *sync.Mutex, wg *sync.WaitGroup) {
+	defer wg.Done()
+	buf := make([]byte, 32*1024)
+	header := make([]byte, 8)
+	header[0] = byte(streamType)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(header[4:8], uint32(n))
+			mu.Lock()
+			_, werr := dst.Write(header)
+			if werr == nil {
+				_, werr = dst.Write(buf[:n])
+			}
+			mu.Unlock()
+			if werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// podmanExecAttachment adapts Podman's stream-based exec attach to the
+// Runtime ExecAttachment shape the Docker implementation exposes.
+type podmanExecAttachment struct {
+	reader *io.PipeReader
+	writer *io.PipeWriter
+}
+
+func (a *podmanExecAttachment) Write(p []byte) (int, error) { return a.writer.Write(p) }
+func (a *podmanExecAttachment) Reader() io.Reader            { return a.reader }
+func (a *podmanExecAttachment) CloseWrite() error            { return a.writer.Close() }
+func (a *podmanExecAttachment) Close() {
+	a.writer.Close()
+	a.reader.Close()
+}
+
+func envSliceToMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		for i := 0; i < len(kv); i++ {
+			if kv[i] == '=' {
+				m[kv[:i]] = kv[i+1:]
+				break
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+// Package runtime abstracts the small set of container operations
+// introspector needs, so it can run against Docker or Podman without the
+// rest of the module caring which.
+package runtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+)
+
+// buildTarArchive wraps contents in a single-file tar archive at path, the
+// format both Runtime implementations' CopyToContainer need since
+// the underlying container APIs only accept tar streams.
+func buildTarArchive(path string, contents []byte) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := tw.WriteHeader(&tar.Header{
+		Name: strings.TrimPrefix(path, "/"),
+		Mode: 0600,
+		Size: int64(len(contents)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = tw.Write(contents)
+	if err != nil {
+		return nil, err
+	}
+	return &buf, tw.Close()
+}
+
+// ContainerSpec describes the container to create for the introspector
+// image, mirroring the handful of fields createIntrospectorContainer sets.
+type ContainerSpec struct {
+	Image       string
+	Name        string
+	Env         []string
+	NetworkMode string
+}
+
+// ContainerInfo is the minimal identity of a container FindContainer needs
+// to return.
+type ContainerInfo struct {
+	ID   string
+	Name string
+}
+
+// ExecConfig describes a command to run inside a running container.
+type ExecConfig struct {
+	Cmd          []string
+	Env          []string
+	AttachStdin  bool
+	AttachStdout bool
+	AttachStderr bool
+}
+
+// ExecAttachment is a live connection to a running exec, multiplexing
+// stdout/stderr on Reader in the same framing stdcopy.StdCopy expects, and
+// accepting stdin writes until CloseWrite.
+type ExecAttachment interface {
+	io.Writer
+	Reader() io.Reader
+	CloseWrite() error
+	Close()
+}
+
+// Runtime is the set of container operations introspector needs from a
+// container engine: pulling the introspector image, managing its container,
+// and execing introspector.py / pg_dump / pg_restore inside it.
+type Runtime interface {
+	PullImage(ctx context.Context, ref string) error
+	FindContainer(ctx context.Context, name string) (*ContainerInfo, error)
+	CreateContainer(ctx context.Context, spec ContainerSpec) (string, error)
+	StartContainer(ctx context.Context, containerID string) error
+	StopAndRemove(ctx context.Context, containerID string) error
+	CopyToContainer(ctx context.Context, containerID, path string, contents []byte) error
+	ExecCreate(ctx context.Context, containerID string, cfg ExecConfig) (string, error)
+	ExecAttach(ctx context.Context, execID string) (ExecAttachment, error)
+	// ExecInspect returns the exit code of a completed exec, so callers can
+	// tell a clean exit from a command that failed inside the container.
+	ExecInspect(ctx context.Context, execID string) (exitCode int, err error)
+}
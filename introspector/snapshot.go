@@ -0,0 +1,195 @@
+package introspector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	rt "github.com/goldfiglabs/go-introspector/introspector/runtime"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// SnapshotOptions controls what Snapshot and Restore archive.
+type SnapshotOptions struct {
+	// SchemaOnly dumps just the schema, skipping resource data. Only
+	// applies when ImportID is zero.
+	SchemaOnly bool
+	// Compress uses pg_dump's custom (compressed) format instead of plain
+	// SQL, matching what Restore expects back. Only applies when ImportID
+	// is zero; per-import snapshots are always plain CSV.
+	Compress bool
+	// ImportID restricts the snapshot to a single import's resources. Zero
+	// means every import in the database.
+	ImportID int
+}
+
+// snapshotTables are the introspector views Snapshot/Restore copy row by
+// row when ImportID is set, since pg_dump has no way to filter rows by
+// column value.
+var snapshotTables = []string{"resource", "resource_attribute", "resource_relation"}
+
+// snapshotTableMarker prefixes a line identifying which table's rows follow
+// it in a per-import snapshot archive.
+const snapshotTableMarker = "-- introspector-table: "
+
+// Snapshot dumps the introspector database to w, so a scan can be saved and
+// later diffed or restored without re-hitting the cloud APIs. With
+// opts.ImportID set, it copies just that import's rows out of
+// snapshotTables as a marker-delimited CSV stream; otherwise it dumps the
+// whole database with pg_dump, in its custom (compressed) format when
+// opts.Compress is set, plain SQL otherwise.
+func (i *Service) Snapshot(ctx context.Context, w io.Writer, opts SnapshotOptions) error {
+	if opts.ImportID != 0 {
+		return i.snapshotByImport(ctx, w, opts.ImportID)
+	}
+	return i.execRaw(ctx, pgDumpArgs(opts), nil, w)
+}
+
+// Restore loads an archive produced by Snapshot back into the introspector
+// database.
+func (i *Service) Restore(ctx context.Context, r io.Reader, opts SnapshotOptions) error {
+	if opts.ImportID != 0 {
+		return i.restoreByImport(ctx, r)
+	}
+	return i.execRaw(ctx, pgRestoreArgs(opts), r, nil)
+}
+
+func pgDumpArgs(opts SnapshotOptions) []string {
+	args := []string{"pg_dump", "--dbname", introspectorDatabase, "--no-owner"}
+	if opts.Compress {
+		args = append(args, "--format", "custom")
+	}
+	if opts.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+	return args
+}
+
+func pgRestoreArgs(opts SnapshotOptions) []string {
+	if opts.Compress {
+		return []string{"pg_restore", "--dbname", introspectorDatabase, "--no-owner", "--clean", "--if-exists"}
+	}
+	return []string{"psql", "--dbname", introspectorDatabase}
+}
+
+// snapshotByImport writes, for each table in snapshotTables, a marker line
+// naming it followed by that import's rows in CSV, via psql's \copy TO
+// STDOUT. import_id is an int the caller controls, not user-supplied SQL,
+// so interpolating it into the \copy command is safe.
+func (i *Service) snapshotByImport(ctx context.Context, w io.Writer, importID int) error {
+	for _, table := range snapshotTables {
+		_, err := fmt.Fprintf(w, "%s%s\n", snapshotTableMarker, table)
+		if err != nil {
+			return errors.Wrap(err, "Failed to write snapshot table marker")
+		}
+		copyCmd := fmt.Sprintf(`\copy (SELECT * FROM %s WHERE import_id = %d) TO STDOUT WITH (FORMAT csv)`, table, importID)
+		args := []string{"psql", "--dbname", introspectorDatabase, "--quiet", "--command", copyCmd}
+		err = i.execRaw(ctx, args, nil, w)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to snapshot table %v", table)
+		}
+	}
+	return nil
+}
+
+// restoreByImport reads a marker-delimited CSV stream produced by
+// snapshotByImport and replays each table's rows via psql's \copy FROM
+// STDIN. It buffers one table's worth of rows at a time rather than
+// streaming, since a single import's data is expected to be small relative
+// to a full database dump.
+func (i *Service) restoreByImport(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	var table string
+	var buf bytes.Buffer
+	flush := func() error {
+		if table == "" {
+			return nil
+		}
+		copyCmd := fmt.Sprintf(`\copy %s FROM STDIN WITH (FORMAT csv)`, table)
+		args := []string{"psql", "--dbname", introspectorDatabase, "--quiet", "--command", copyCmd}
+		err := i.execRaw(ctx, args, bytes.NewReader(buf.Bytes()), nil)
+		buf.Reset()
+		return errors.Wrapf(err, "Failed to restore table %v", table)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, snapshotTableMarker) {
+			if err := flush(); err != nil {
+				return err
+			}
+			table = strings.TrimPrefix(line, snapshotTableMarker)
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "Failed to read snapshot archive")
+	}
+	return flush()
+}
+
+// execRaw runs argv in the introspector container, writing stdin (if non-nil)
+// to the process and copying its stdout (if out is non-nil) to out. Unlike
+// runCommand, it moves raw bytes rather than parsing lines, since pg_dump and
+// pg_restore archives aren't line-oriented text.
+func (i *Service) execRaw(ctx context.Context, argv []string, stdin io.Reader, out io.Writer) error {
+	execID, err := i.rt.ExecCreate(ctx, i.containerID, rt.ExecConfig{
+		Cmd:          argv,
+		AttachStdin:  stdin != nil,
+		AttachStdout: out != nil,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to create exec")
+	}
+	resp, err := i.rt.ExecAttach(ctx, execID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to attach to exec")
+	}
+	defer resp.Close()
+
+	if stdin != nil {
+		go func() {
+			_, copyErr := io.Copy(resp, stdin)
+			if copyErr != nil {
+				log.Warnf("Failed to write exec stdin: %v", copyErr)
+			}
+			resp.CloseWrite()
+		}()
+	}
+
+	stderr := stderrLineWriter(defaultOutputHandler{})
+	outputDone := make(chan error)
+	go func() {
+		if out != nil {
+			_, copyErr := stdcopy.StdCopy(out, stderr, resp.Reader())
+			stderr.flush()
+			outputDone <- copyErr
+		} else {
+			stdout := stdoutLineWriter(defaultOutputHandler{})
+			_, copyErr := stdcopy.StdCopy(stdout, stderr, resp.Reader())
+			stdout.flush()
+			stderr.flush()
+			outputDone <- copyErr
+		}
+	}()
+
+	select {
+	case err := <-outputDone:
+		if err != nil {
+			return errors.Wrap(err, "Failed to stream exec output")
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}